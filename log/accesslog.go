@@ -0,0 +1,292 @@
+package log
+
+import (
+	"bufio"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type accessLogOptions struct {
+	level          slog.Level
+	sampler        func(*http.Request) bool
+	trustedProxies []*net.IPNet
+}
+
+// AccessLogOption customizes AccessLogMiddleware.
+type AccessLogOption func(*accessLogOptions)
+
+// WithAccessLogLevel sets the slog level access log records are emitted
+// at. Defaults to slog.LevelInfo.
+func WithAccessLogLevel(l slog.Level) AccessLogOption {
+	return func(o *accessLogOptions) {
+		o.level = l
+	}
+}
+
+// WithAccessLogSampler restricts logging to requests for which fn returns
+// true, letting high-volume endpoints (e.g. health checks) be sampled or
+// skipped entirely.
+func WithAccessLogSampler(fn func(*http.Request) bool) AccessLogOption {
+	return func(o *accessLogOptions) {
+		o.sampler = fn
+	}
+}
+
+// WithTrustedProxies lists the CIDRs a request's RemoteAddr must match
+// before its X-Forwarded-For/Forwarded header is trusted for client.ip.
+// Without this, client.ip is always the host part of r.RemoteAddr.
+func WithTrustedProxies(cidrs ...string) AccessLogOption {
+	return func(o *accessLogOptions) {
+		for _, cidr := range cidrs {
+			if _, n, err := net.ParseCIDR(cidr); err == nil {
+				o.trustedProxies = append(o.trustedProxies, n)
+			}
+		}
+	}
+}
+
+// AccessLogMiddleware wraps the ResponseWriter to capture the status code,
+// bytes written, and duration of each request, then emits a single
+// ECS-compliant access log record on completion.
+func AccessLogMiddleware(opts ...AccessLogOption) func(http.Handler) http.Handler {
+	o := accessLogOptions{level: slog.LevelInfo}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if o.sampler != nil && !o.sampler(r) {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rec := newResponseRecorder(w)
+			h.ServeHTTP(rec, r)
+			duration := time.Since(start)
+
+			slog.LogAttrs(r.Context(), o.level, "HTTP Request handled",
+				slog.Group("http",
+					slog.Group("request",
+						slog.String("method", r.Method),
+						slog.Group("body", slog.Int64("bytes", requestBodyBytes(r))),
+					),
+					slog.Group("response",
+						slog.Int("status_code", rec.status()),
+						slog.Group("body", slog.Int("bytes", rec.bytesWritten())),
+					),
+				),
+				slog.Group("url",
+					slog.String("path", r.URL.Path),
+					slog.String("query", r.URL.RawQuery),
+					slog.String("scheme", urlScheme(r, o.trustedProxies)),
+				),
+				slog.Group("user_agent", slog.String("original", r.UserAgent())),
+				slog.Group("client", slog.String("ip", clientIP(r, o.trustedProxies))),
+				slog.Group("event", slog.Int64("duration", duration.Nanoseconds())),
+			)
+		})
+	}
+}
+
+// requestBodyBytes returns r.ContentLength, clamped to 0. ContentLength is
+// -1 for chunked/unknown-length requests, which would otherwise surface as
+// a negative http.request.body.bytes.
+func requestBodyBytes(r *http.Request) int64 {
+	if r.ContentLength < 0 {
+		return 0
+	}
+	return r.ContentLength
+}
+
+// trustedRemoteAddr reports whether r.RemoteAddr matches one of the
+// configured trusted proxy CIDRs.
+func trustedRemoteAddr(r *http.Request, trustedProxies []*net.IPNet) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP resolves the originating client IP, honoring
+// X-Forwarded-For/Forwarded only when the immediate peer is a trusted proxy.
+func clientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	if len(trustedProxies) > 0 && trustedRemoteAddr(r, trustedProxies) {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			return strings.TrimSpace(strings.Split(fwd, ",")[0])
+		}
+		if fwd := r.Header.Get("Forwarded"); fwd != "" {
+			if ip := parseForwardedFor(fwd); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// parseForwardedFor extracts the "for=" value from the first element of an
+// RFC 7239 Forwarded header.
+func parseForwardedFor(header string) string {
+	first := strings.Split(header, ",")[0]
+	for _, part := range strings.Split(first, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && strings.EqualFold(strings.TrimSpace(k), "for") {
+			return strings.Trim(strings.TrimSpace(v), `"`)
+		}
+	}
+	return ""
+}
+
+// urlScheme resolves the request scheme, honoring X-Forwarded-Proto from a
+// trusted proxy.
+func urlScheme(r *http.Request, trustedProxies []*net.IPNet) string {
+	if len(trustedProxies) > 0 && trustedRemoteAddr(r, trustedProxies) {
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+			return proto
+		}
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// responseRecorder captures the status code and bytes written for an
+// http.ResponseWriter.
+type responseRecorder struct {
+	http.ResponseWriter
+	wroteHeader bool
+	code        int
+	bytes       int
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.code = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+func (r *responseRecorder) status() int {
+	if !r.wroteHeader {
+		return http.StatusOK
+	}
+	return r.code
+}
+
+func (r *responseRecorder) bytesWritten() int {
+	return r.bytes
+}
+
+// newResponseRecorder wraps w in a responseRecorder, preserving whichever
+// of http.Flusher, http.Hijacker, and http.Pusher w itself implements, so
+// wrapping doesn't break streaming or WebSocket handlers downstream.
+func newResponseRecorder(w http.ResponseWriter) interface {
+	http.ResponseWriter
+	status() int
+	bytesWritten() int
+} {
+	base := &responseRecorder{ResponseWriter: w}
+
+	_, isFlusher := w.(http.Flusher)
+	_, isHijacker := w.(http.Hijacker)
+	_, isPusher := w.(http.Pusher)
+
+	switch {
+	case isFlusher && isHijacker && isPusher:
+		return &flusherHijackerPusherRecorder{base}
+	case isFlusher && isHijacker:
+		return &flusherHijackerRecorder{base}
+	case isFlusher && isPusher:
+		return &flusherPusherRecorder{base}
+	case isHijacker && isPusher:
+		return &hijackerPusherRecorder{base}
+	case isFlusher:
+		return &flusherRecorder{base}
+	case isHijacker:
+		return &hijackerRecorder{base}
+	case isPusher:
+		return &pusherRecorder{base}
+	default:
+		return base
+	}
+}
+
+type flusherRecorder struct{ *responseRecorder }
+
+func (r *flusherRecorder) Flush() { r.ResponseWriter.(http.Flusher).Flush() }
+
+type hijackerRecorder struct{ *responseRecorder }
+
+func (r *hijackerRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+type pusherRecorder struct{ *responseRecorder }
+
+func (r *pusherRecorder) Push(target string, opts *http.PushOptions) error {
+	return r.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+type flusherHijackerRecorder struct{ *responseRecorder }
+
+func (r *flusherHijackerRecorder) Flush() { r.ResponseWriter.(http.Flusher).Flush() }
+func (r *flusherHijackerRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+type flusherPusherRecorder struct{ *responseRecorder }
+
+func (r *flusherPusherRecorder) Flush() { r.ResponseWriter.(http.Flusher).Flush() }
+func (r *flusherPusherRecorder) Push(target string, opts *http.PushOptions) error {
+	return r.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+type hijackerPusherRecorder struct{ *responseRecorder }
+
+func (r *hijackerPusherRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.ResponseWriter.(http.Hijacker).Hijack()
+}
+func (r *hijackerPusherRecorder) Push(target string, opts *http.PushOptions) error {
+	return r.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+type flusherHijackerPusherRecorder struct{ *responseRecorder }
+
+func (r *flusherHijackerPusherRecorder) Flush() { r.ResponseWriter.(http.Flusher).Flush() }
+func (r *flusherHijackerPusherRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.ResponseWriter.(http.Hijacker).Hijack()
+}
+func (r *flusherHijackerPusherRecorder) Push(target string, opts *http.PushOptions) error {
+	return r.ResponseWriter.(http.Pusher).Push(target, opts)
+}
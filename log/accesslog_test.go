@@ -0,0 +1,135 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// withDefaultLogger points the package-level slog default at a handler
+// writing to buf for the duration of the test, restoring the previous
+// default on cleanup. AccessLogMiddleware logs via slog.LogAttrs, which
+// always goes through slog.Default().
+func withDefaultLogger(t *testing.T, buf *bytes.Buffer) {
+	t.Helper()
+	prev := slog.Default()
+	slog.SetDefault(slog.New(NewHandler(buf, WithFormat(FormatJSON))))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+}
+
+func mustCIDR(t *testing.T, cidr string) []*net.IPNet {
+	t.Helper()
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatalf("invalid test CIDR %q: %v", cidr, err)
+	}
+	return []*net.IPNet{n}
+}
+
+func TestAccessLogMiddleware_CapturesStatusAndBytes(t *testing.T) {
+	var buf bytes.Buffer
+	withDefaultLogger(t, &buf)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/widgets?x=1", nil)
+	AccessLogMiddleware()(next).ServeHTTP(rr, req)
+
+	var out map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("failed to parse log output: %v\n%s", err, buf.String())
+	}
+
+	httpGroup := out["http"].(map[string]any)
+	response := httpGroup["response"].(map[string]any)
+	if response["status_code"] != float64(http.StatusCreated) {
+		t.Errorf("expected status_code %d, got %v", http.StatusCreated, response["status_code"])
+	}
+	if body := response["body"].(map[string]any); body["bytes"] != float64(len("hello")) {
+		t.Errorf("expected response body bytes %d, got %v", len("hello"), body["bytes"])
+	}
+
+	urlGroup := out["url"].(map[string]any)
+	if urlGroup["path"] != "/widgets" {
+		t.Errorf("expected url.path %q, got %v", "/widgets", urlGroup["path"])
+	}
+
+	event := out["event"].(map[string]any)
+	if _, ok := event["duration"]; !ok {
+		t.Error("expected event.duration to be present")
+	}
+}
+
+func TestAccessLogMiddleware_SamplerSkipsLogging(t *testing.T) {
+	var buf bytes.Buffer
+	withDefaultLogger(t, &buf)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	mw := AccessLogMiddleware(WithAccessLogSampler(func(r *http.Request) bool { return r.URL.Path != "/healthz" }))
+	mw(next).ServeHTTP(rr, req)
+
+	if buf.Len() != 0 {
+		t.Errorf("expected sampled-out request to produce no log output, got %q", buf.String())
+	}
+}
+
+func TestRequestBodyBytes_ClampsUnknownLength(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.ContentLength = -1
+
+	if got := requestBodyBytes(req); got != 0 {
+		t.Errorf("expected unknown content length to clamp to 0, got %d", got)
+	}
+}
+
+func TestClientIP_UntrustedProxyIgnoresForwardedFor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.5:1234"
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+
+	if ip := clientIP(req, nil); ip != "203.0.113.5" {
+		t.Errorf("expected untrusted RemoteAddr, got %q", ip)
+	}
+}
+
+func TestClientIP_TrustedProxyHonorsForwardedFor(t *testing.T) {
+	cidr := mustCIDR(t, "203.0.113.0/24")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.5:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7, 203.0.113.5")
+
+	if ip := clientIP(req, cidr); ip != "198.51.100.7" {
+		t.Errorf("expected forwarded client IP, got %q", ip)
+	}
+}
+
+func TestURLScheme_TrustedProxyHonorsForwardedProto(t *testing.T) {
+	cidr := mustCIDR(t, "203.0.113.0/24")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.5:1234"
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	if scheme := urlScheme(req, cidr); scheme != "https" {
+		t.Errorf("expected scheme %q, got %q", "https", scheme)
+	}
+}
+
+func TestURLScheme_DefaultsToHTTP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if scheme := urlScheme(req, nil); scheme != "http" {
+		t.Errorf("expected scheme %q, got %q", "http", scheme)
+	}
+}
@@ -4,14 +4,8 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
-)
-
-// slogAttributes is the attributes context key type
-type slogAttributes string
 
-var (
-	labelsAttributes = slogAttributes("labels")
-	ecsAttributes    = slogAttributes("ecs")
+	"github.com/simodima/serene/log/ecsattrs"
 )
 
 // LogExtract is a function extract informations from log
@@ -28,30 +22,22 @@ func ExtractHeaderRename(name string, rename string) LogExtract {
 
 // AddLabelAttrs appends the given slog attributes to the context.
 func AddLabelAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
-	attrs = append(attrs, GetLabelAttrs(ctx)...)
-	return context.WithValue(ctx, labelsAttributes, attrs)
+	return ecsattrs.AddLabels(ctx, attrs...)
 }
 
 // addECSAttrs appends the given slog attributes to the context.
 func addECSAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
-	attrs = append(attrs, GetECSAttrs(ctx)...)
-	return context.WithValue(ctx, ecsAttributes, attrs)
+	return ecsattrs.AddECS(ctx, attrs...)
 }
 
 // GetLabelAttrs gets the slog attributes from the give context.
 func GetLabelAttrs(ctx context.Context) []slog.Attr {
-	if loadedAttrs, ok := ctx.Value(labelsAttributes).([]slog.Attr); ok {
-		return loadedAttrs
-	}
-	return []slog.Attr{}
+	return ecsattrs.Labels(ctx)
 }
 
 // GetECSAttrs gets the slog attributes from the give context.
 func GetECSAttrs(ctx context.Context) []slog.Attr {
-	if loadedAttrs, ok := ctx.Value(ecsAttributes).([]slog.Attr); ok {
-		return loadedAttrs
-	}
-	return []slog.Attr{}
+	return ecsattrs.ECS(ctx)
 }
 
 type middlewareOptions struct {
@@ -0,0 +1,141 @@
+package log
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Default header names used by CorrelationMiddleware and CorrelationRoundTripper.
+const (
+	DefaultOperationIDHeader = "X-Operation-ID"
+	DefaultExternalIDHeader  = "X-Request-ID"
+)
+
+// ECS fields CorrelationMiddleware stashes in the request context.
+const (
+	transactionIDKey = "transaction.id"
+	traceIDKey       = "trace.id"
+)
+
+type correlationOptions struct {
+	operationIDHeader string
+	externalIDHeader  string
+}
+
+// CorrelationOption customizes CorrelationMiddleware and CorrelationRoundTripper.
+type CorrelationOption func(*correlationOptions)
+
+// WithOperationIDHeader overrides the header used to read/propagate the
+// per-request operation ID. Defaults to DefaultOperationIDHeader.
+func WithOperationIDHeader(name string) CorrelationOption {
+	return func(o *correlationOptions) {
+		o.operationIDHeader = name
+	}
+}
+
+// WithExternalIDHeader overrides the header used to read/propagate the
+// caller-supplied correlation ID. Defaults to DefaultExternalIDHeader.
+func WithExternalIDHeader(name string) CorrelationOption {
+	return func(o *correlationOptions) {
+		o.externalIDHeader = name
+	}
+}
+
+func newCorrelationOptions(opts []CorrelationOption) correlationOptions {
+	o := correlationOptions{
+		operationIDHeader: DefaultOperationIDHeader,
+		externalIDHeader:  DefaultExternalIDHeader,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// CorrelationMiddleware stamps every request with a transaction.id (read
+// from the configured header, or generated when absent) and, when the
+// caller supplied one, a trace.id. Both are stashed via addECSAttrs so
+// ECSHandler.Handle emits them as top-level ECS fields rather than under
+// "labels", and are echoed back on the configured operation-id header so
+// callers can correlate their own logs.
+func CorrelationMiddleware(opts ...CorrelationOption) func(http.Handler) http.Handler {
+	o := newCorrelationOptions(opts)
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			operationID := r.Header.Get(o.operationIDHeader)
+			if operationID == "" {
+				operationID = newCorrelationID()
+			}
+
+			ctx := addECSAttrs(r.Context(), slog.String(transactionIDKey, operationID))
+			if externalID := r.Header.Get(o.externalIDHeader); externalID != "" {
+				ctx = addECSAttrs(ctx, slog.String(traceIDKey, externalID))
+			}
+
+			w.Header().Set(o.operationIDHeader, operationID)
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// WithCorrelation returns a new, independent context carrying the
+// correlation attributes from ctx, so a background worker spawned outside
+// the request's lifetime keeps logging under the same operation once the
+// originating request context is canceled.
+func WithCorrelation(ctx context.Context) context.Context {
+	return addECSAttrs(context.Background(), GetECSAttrs(ctx)...)
+}
+
+// CorrelationRoundTripper wraps an http.RoundTripper, stamping the
+// correlation IDs carried in the request context onto every outbound
+// request so logs on both sides of a call can be joined.
+type CorrelationRoundTripper struct {
+	Next http.RoundTripper
+	opts correlationOptions
+}
+
+// NewCorrelationRoundTripper wraps next, or http.DefaultTransport if nil.
+func NewCorrelationRoundTripper(next http.RoundTripper, opts ...CorrelationOption) *CorrelationRoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &CorrelationRoundTripper{Next: next, opts: newCorrelationOptions(opts)}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CorrelationRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+
+	for _, a := range GetECSAttrs(req.Context()) {
+		switch a.Key {
+		case transactionIDKey:
+			req.Header.Set(t.opts.operationIDHeader, a.Value.String())
+		case traceIDKey:
+			req.Header.Set(t.opts.externalIDHeader, a.Value.String())
+		}
+	}
+
+	return t.Next.RoundTrip(req)
+}
+
+// newCorrelationID generates a random UUIDv4, used as the default
+// transaction.id when the caller didn't supply one. crypto/rand.Read only
+// fails when the system CSPRNG itself is unusable, which we can't recover
+// from; rather than stamp every request with an empty (and silently
+// useless) transaction.id, fall back to a timestamp-based identifier so
+// correlation still works, just with a higher (still practically
+// negligible) collision chance.
+func newCorrelationID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("fallback-%d", time.Now().UnixNano())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
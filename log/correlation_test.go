@@ -0,0 +1,117 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCorrelationMiddleware_GeneratesAndEchoesOperationID(t *testing.T) {
+	var gotTransactionID string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attrs := GetECSAttrs(r.Context())
+		for _, a := range attrs {
+			if a.Key == transactionIDKey {
+				gotTransactionID = a.Value.String()
+			}
+		}
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	CorrelationMiddleware()(next).ServeHTTP(rr, req)
+
+	if gotTransactionID == "" {
+		t.Fatal("expected a generated transaction.id to reach the handler")
+	}
+
+	if echoed := rr.Header().Get(DefaultOperationIDHeader); echoed != gotTransactionID {
+		t.Errorf("expected %s header to echo the transaction.id, got %q want %q", DefaultOperationIDHeader, echoed, gotTransactionID)
+	}
+}
+
+func TestCorrelationMiddleware_HonorsIncomingHeaders(t *testing.T) {
+	var gotTransactionID, gotTraceID string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, a := range GetECSAttrs(r.Context()) {
+			switch a.Key {
+			case transactionIDKey:
+				gotTransactionID = a.Value.String()
+			case traceIDKey:
+				gotTraceID = a.Value.String()
+			}
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(DefaultOperationIDHeader, "op-123")
+	req.Header.Set(DefaultExternalIDHeader, "ext-456")
+
+	CorrelationMiddleware()(next).ServeHTTP(httptest.NewRecorder(), req)
+
+	if gotTransactionID != "op-123" {
+		t.Errorf("expected transaction.id %q, got %q", "op-123", gotTransactionID)
+	}
+	if gotTraceID != "ext-456" {
+		t.Errorf("expected trace.id %q, got %q", "ext-456", gotTraceID)
+	}
+}
+
+func TestCorrelationRoundTripper_StampsOutboundHeaders(t *testing.T) {
+	var gotOperationHeader, gotExternalHeader string
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotOperationHeader = req.Header.Get(DefaultOperationIDHeader)
+		gotExternalHeader = req.Header.Get(DefaultExternalIDHeader)
+		return httptest.NewRecorder().Result(), nil
+	})
+
+	ctx := addECSAttrs(context.Background(), slog.String(transactionIDKey, "op-789"), slog.String(traceIDKey, "ext-012"))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.test/", nil)
+
+	rt := NewCorrelationRoundTripper(next)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotOperationHeader != "op-789" {
+		t.Errorf("expected %s %q, got %q", DefaultOperationIDHeader, "op-789", gotOperationHeader)
+	}
+	if gotExternalHeader != "ext-012" {
+		t.Errorf("expected %s %q, got %q", DefaultExternalIDHeader, "ext-012", gotExternalHeader)
+	}
+}
+
+func TestWithCorrelation_SurvivesOriginalContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = addECSAttrs(ctx, slog.String(transactionIDKey, "op-999"))
+
+	detached := WithCorrelation(ctx)
+	cancel()
+
+	if err := detached.Err(); err != nil {
+		t.Fatalf("expected detached context to outlive cancellation, got %v", err)
+	}
+
+	var found bool
+	for _, a := range GetECSAttrs(detached) {
+		if a.Key == transactionIDKey && a.Value.String() == "op-999" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected transaction.id to carry over into the detached context")
+	}
+}
+
+func TestNewCorrelationID_NeverEmpty(t *testing.T) {
+	if id := newCorrelationID(); id == "" {
+		t.Error("expected a non-empty correlation ID")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
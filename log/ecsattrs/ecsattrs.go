@@ -0,0 +1,86 @@
+// Package ecsattrs stores the request-scoped label and ECS attributes that
+// flow from HTTP middleware (package log) into ECSHandler (package
+// ecshandler). It has no dependencies of its own so both packages can
+// depend on it without forming an import cycle.
+package ecsattrs
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+type contextKey string
+
+var (
+	labelsKey = contextKey("labels")
+	ecsKey    = contextKey("ecs")
+)
+
+// AddLabels appends the given slog attributes to the context.
+func AddLabels(ctx context.Context, attrs ...slog.Attr) context.Context {
+	attrs = append(attrs, Labels(ctx)...)
+	return context.WithValue(ctx, labelsKey, attrs)
+}
+
+// AddECS appends the given slog attributes to the context.
+func AddECS(ctx context.Context, attrs ...slog.Attr) context.Context {
+	attrs = append(attrs, ECS(ctx)...)
+	return context.WithValue(ctx, ecsKey, attrs)
+}
+
+// Labels gets the slog attributes from the given context.
+func Labels(ctx context.Context) []slog.Attr {
+	if loadedAttrs, ok := ctx.Value(labelsKey).([]slog.Attr); ok {
+		return loadedAttrs
+	}
+	return []slog.Attr{}
+}
+
+// ECS gets the slog attributes from the given context.
+func ECS(ctx context.Context) []slog.Attr {
+	if loadedAttrs, ok := ctx.Value(ecsKey).([]slog.Attr); ok {
+		return loadedAttrs
+	}
+	return []slog.Attr{}
+}
+
+// Namespace builds nested slog.Attr groups from a flat list of dotted-key
+// attrs, e.g. [http.response.status_code=200] becomes
+// [http={response={status_code=200}}], merging attrs that share a common
+// prefix under the same group. Shared by log.NamespaceAttrs and
+// ecshandler's WithECSNamespace.
+func Namespace(attrs []slog.Attr) []slog.Attr {
+	type node struct {
+		attrs    []slog.Attr
+		children map[string]*node
+		order    []string
+	}
+	root := &node{children: map[string]*node{}}
+
+	for _, a := range attrs {
+		parts := strings.Split(a.Key, ".")
+		n := root
+		for _, p := range parts[:len(parts)-1] {
+			child, ok := n.children[p]
+			if !ok {
+				child = &node{children: map[string]*node{}}
+				n.children[p] = child
+				n.order = append(n.order, p)
+			}
+			n = child
+		}
+		n.attrs = append(n.attrs, slog.Attr{Key: parts[len(parts)-1], Value: a.Value})
+	}
+
+	var build func(n *node) []slog.Attr
+	build = func(n *node) []slog.Attr {
+		out := append([]slog.Attr{}, n.attrs...)
+		for _, key := range n.order {
+			out = append(out, slog.Attr{Key: key, Value: slog.GroupValue(build(n.children[key])...)})
+		}
+		return out
+	}
+
+	return build(root)
+}
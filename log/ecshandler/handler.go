@@ -2,11 +2,16 @@ package ecshandler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"reflect"
 	"runtime"
+	"strings"
 
-	"github.com/simodima/serene/log"
+	"github.com/simodima/serene/log/ecsattrs"
 )
 
 // Package constants for ECS (Elastic Common Schema) and logger metadata
@@ -29,25 +34,32 @@ const (
 	functionKey  = "function"   // Function name key
 	labelsKey    = "labels"     // Labels group key
 
-	// Error keys (currently commented out)
-	// errorKey           = "error"
-	// errorMessageKey    = "message"
-	// errorStackTraceKey = "stack_trace"
+	errorKey           = "error"       // Error group key
+	errorMessageKey    = "message"     // Error message key, within the error group
+	errorTypeKey       = "type"        // Error type key, within the error group
+	errorStackTraceKey = "stack_trace" // Error stack trace key, within the error group
 )
 
 // Option represents a functional option to customize the ECSHandler.
 type Option func(*opts)
 
+// defaultLevel is the minimum logging level used when neither WithLevel nor
+// WithLeveler is given.
+const defaultLevel = slog.LevelDebug
+
 // opts is a struct that holds configuration options for the ECSHandler.
 type opts struct {
-	level        slog.Level                                   // Minimum logging level
-	levelRenamer func(slog.Level) string                      // Function to rename logging levels
-	replaceAttr  func(groups []string, a slog.Attr) slog.Attr // Function to replace/modify attributes
+	level           *slog.LevelVar                               // Minimum logging level, settable at runtime
+	levelRenamer    func(slog.Level) string                      // Function to rename logging levels
+	replaceAttr     func(groups []string, a slog.Attr) slog.Attr // Function to replace/modify attributes
+	forceStackTrace bool                                         // Force runtime.Callers capture for errors without embedded frames
+	writer          io.Writer                                    // Destination for JSON-formatted logs
+	namespace       *string                                      // ECS namespace prefix; nil disables dotted-key nesting
 }
 
 // defaultOptions defines the default settings for the ECSHandler.
 var defaultOptions = opts{
-	level: slog.LevelDebug, // Default logging level: Debug
+	writer: os.Stdout,
 	levelRenamer: func(level slog.Level) string { // Default level renamer: uses level's string representation
 		return level.String()
 	},
@@ -62,10 +74,22 @@ var defaultOptions = opts{
 	},
 }
 
-// WithLevel sets the minimum logging level for the ECSHandler.
+// WithLevel sets the minimum logging level for the ECSHandler. It is
+// equivalent to calling Level().Set(l) on the resulting handler.
 func WithLevel(l slog.Level) Option {
 	return func(o *opts) {
-		o.level = l
+		if o.level == nil {
+			o.level = new(slog.LevelVar)
+		}
+		o.level.Set(l)
+	}
+}
+
+// WithLeveler lets callers share a single *slog.LevelVar across multiple
+// handlers, so flipping it (e.g. via LevelHandler) affects all of them.
+func WithLeveler(lv *slog.LevelVar) Option {
+	return func(o *opts) {
+		o.level = lv
 	}
 }
 
@@ -83,10 +107,45 @@ func WithReplaceAttr(fn func(groups []string, a slog.Attr) slog.Attr) Option {
 	}
 }
 
+// WithStackTrace forces error.stack_trace capture via runtime.Callers for
+// errors that don't carry their own frames (i.e. neither a StackTrace()
+// method à la pkg/errors nor a fmt.Formatter giving a distinct "%+v"). When
+// false (the default), such errors only get a captured stack at Error level
+// and above.
+func WithStackTrace(force bool) Option {
+	return func(o *opts) {
+		o.forceStackTrace = force
+	}
+}
+
+// WithWriter sets the destination the ECSHandler writes JSON-formatted
+// logs to. Defaults to os.Stdout.
+func WithWriter(w io.Writer) Option {
+	return func(o *opts) {
+		o.writer = w
+	}
+}
+
+// WithECSNamespace enables dotted-key nesting for attributes whose key
+// starts with "prefix.", e.g. "http.response.status_code" becomes the
+// nested group http: {response: {status_code: ...}} instead of a flat key
+// with dots. An empty prefix nests every dotted-key attribute. Applies to
+// both the record's own attributes and context-provided ones (see
+// log.GetECSAttrs).
+func WithECSNamespace(prefix string) Option {
+	return func(o *opts) {
+		o.namespace = &prefix
+	}
+}
+
 // ECSHandler is a log handler that formats logs following the ECS (Elastic Common Schema).
 type ECSHandler struct {
 	*slog.JSONHandler                         // Underlying handler for writing JSON-formatted logs
 	levelRenamer      func(slog.Level) string // Custom function for renaming levels
+	level             *slog.LevelVar          // Runtime-settable minimum logging level
+	forceStackTrace   bool                    // Force runtime.Callers capture for frame-less errors
+	namespace         *string                 // ECS namespace prefix; nil disables dotted-key nesting
+	groups            []string                // Group names opened via WithGroup, applied only to the record's own attrs (see WithGroup)
 }
 
 // NewECSHandler creates a new ECSHandler with the specified options.
@@ -97,29 +156,98 @@ func NewECSHandler(options ...Option) *ECSHandler {
 		op(&o) // Apply each Option to override defaults
 	}
 
+	if o.level == nil {
+		o.level = new(slog.LevelVar)
+		o.level.Set(defaultLevel)
+	}
+
 	// Create a JSONHandler with the configured options
-	h := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level:       o.level,       // Set log level
+	h := slog.NewJSONHandler(o.writer, &slog.HandlerOptions{
+		Level:       o.level,       // Set log level, re-read on every record
 		ReplaceAttr: o.replaceAttr, // Set attribute replacement function
 	})
 
 	// Return an ECSHandler wrapping the JSONHandler
 	return &ECSHandler{
-		JSONHandler:  h,
-		levelRenamer: o.levelRenamer,
+		JSONHandler:     h,
+		levelRenamer:    o.levelRenamer,
+		level:           o.level,
+		forceStackTrace: o.forceStackTrace,
+		namespace:       o.namespace,
+	}
+}
+
+// Level returns the LevelVar backing this handler's minimum logging level,
+// so it can be read or mutated at runtime (e.g. from LevelHandler).
+func (h *ECSHandler) Level() *slog.LevelVar {
+	return h.level
+}
+
+// WithAttrs returns a new ECSHandler wrapping the JSONHandler derived via
+// WithAttrs, preserving the ECS enrichment and configuration that embedding
+// *slog.JSONHandler directly would otherwise lose.
+func (h *ECSHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ECSHandler{
+		JSONHandler:     h.JSONHandler.WithAttrs(attrs).(*slog.JSONHandler),
+		levelRenamer:    h.levelRenamer,
+		level:           h.level,
+		forceStackTrace: h.forceStackTrace,
+		namespace:       h.namespace,
+		groups:          h.groups,
+	}
+}
+
+// WithGroup returns a new ECSHandler that nests the record's own attrs
+// (including any promoted error group) under name. Unlike WithAttrs, this
+// does NOT call JSONHandler.WithGroup: Handle writes ECS metadata
+// (@timestamp, message, ecs.version, labels, log.origin, ...) through the
+// same embedded JSONHandler used for the record's attrs, so grouping it
+// would nest that metadata under name too instead of just the record's own
+// attrs. Tracking the group name ourselves and applying it only around the
+// record's attrs in Handle keeps ECS fields top-level as intended.
+func (h *ECSHandler) WithGroup(name string) slog.Handler {
+	return &ECSHandler{
+		JSONHandler:     h.JSONHandler,
+		levelRenamer:    h.levelRenamer,
+		level:           h.level,
+		forceStackTrace: h.forceStackTrace,
+		namespace:       h.namespace,
+		groups:          append(append([]string{}, h.groups...), name),
 	}
 }
 
 // Handle processes a log record (slog.Record) and transforms it to match ECS requirements.
 // Adds ECS-compliant fields and passes the record to the JSONHandler.
 func (h *ECSHandler) Handle(ctx context.Context, r slog.Record) error {
-	labels := log.GetLabelAttrs(ctx)
-	plainAttributes := log.GetECSAttrs(ctx)
+	labels := ecsattrs.Labels(ctx)
+	plainAttributes := h.applyNamespace(ecsattrs.ECS(ctx))
 
 	// Obtain stack frame information (e.g., file, line, function) for the log origin
 	fs := runtime.CallersFrames([]uintptr{r.PC})
 	f, _ := fs.Next() // Retrieve the next frame
 
+	// Pull out the record's own attributes, lifting the first error value
+	// into the ECS error group instead of leaving it as a flat key. Any
+	// further error-valued attrs are kept too (rendered via Error(), since
+	// the ECS schema only has room for one "error" group per record) rather
+	// than being silently dropped.
+	kept := make([]slog.Attr, 0, r.NumAttrs())
+	var errAttr slog.Attr
+	hasErr := false
+	r.Attrs(func(a slog.Attr) bool {
+		if err, ok := a.Value.Any().(error); ok {
+			if !hasErr {
+				errAttr = h.errorAttr(err, r.Level)
+				hasErr = true
+			} else {
+				kept = append(kept, slog.String(a.Key, err.Error()))
+			}
+			return true
+		}
+		kept = append(kept, a)
+		return true
+	})
+
 	allAttributes := append([]slog.Attr{
 		slog.Time(timestampKey, r.Time),                     // Add log timestamp
 		slog.String(messageKey, r.Message),                  // Add log message
@@ -136,9 +264,140 @@ func (h *ECSHandler) Handle(ctx context.Context, r slog.Record) error {
 		),
 	}, plainAttributes...)
 
-	// Add ECS-compliant attributes to the log record
-	r.AddAttrs(allAttributes...)
+	// The record's own attrs (plus the promoted error group) nest under
+	// any groups opened via WithGroup; the ECS metadata above never does,
+	// so it always appears at the top level.
+	recordAttrs := h.applyNamespace(kept)
+	if hasErr {
+		recordAttrs = append(recordAttrs, errAttr)
+	}
+	recordAttrs = wrapGroups(h.groups, recordAttrs)
+
+	// Rebuild the record without its original attributes, then add them
+	// back alongside the ECS ones so the error (if any) is replaced by its group.
+	enriched := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	enriched.AddAttrs(recordAttrs...)
+	enriched.AddAttrs(allAttributes...)
 
 	// Pass the enriched log record to the underlying JSONHandler
-	return h.JSONHandler.Handle(ctx, r)
+	return h.JSONHandler.Handle(ctx, enriched)
+}
+
+// wrapGroups nests attrs under each name in groups, outermost-last-opened
+// first, so e.g. groups=["a","b"] produces a={b={...attrs}}.
+func wrapGroups(groups []string, attrs []slog.Attr) []slog.Attr {
+	for i := len(groups) - 1; i >= 0; i-- {
+		attrs = []slog.Attr{{Key: groups[i], Value: slog.GroupValue(attrs...)}}
+	}
+	return attrs
+}
+
+// applyNamespace nests the subset of attrs whose key matches the
+// configured WithECSNamespace prefix into ECS groups, leaving the rest
+// untouched. It's a no-op when WithECSNamespace wasn't given.
+func (h *ECSHandler) applyNamespace(attrs []slog.Attr) []slog.Attr {
+	if h.namespace == nil {
+		return attrs
+	}
+
+	prefix := *h.namespace
+	var matched, rest []slog.Attr
+	for _, a := range attrs {
+		if prefix == "" || strings.HasPrefix(a.Key, prefix+".") {
+			matched = append(matched, a)
+		} else {
+			rest = append(rest, a)
+		}
+	}
+
+	if len(matched) == 0 {
+		return attrs
+	}
+	return append(rest, ecsattrs.Namespace(matched)...)
+}
+
+// errorAttr builds the ECS "error" group for err: error.message,
+// error.type, and, when available or forced, error.stack_trace.
+func (h *ECSHandler) errorAttr(err error, level slog.Level) slog.Attr {
+	fields := []slog.Attr{
+		slog.String(errorMessageKey, err.Error()),
+		slog.String(errorTypeKey, reflect.TypeOf(err).String()),
+	}
+
+	if stack := h.captureStack(err, level); stack != "" {
+		fields = append(fields, slog.String(errorStackTraceKey, stack))
+	}
+
+	return slog.Attr{Key: errorKey, Value: slog.GroupValue(fields...)}
+}
+
+// errorStackFrames looks up a StackTrace() method on e by reflection and,
+// if its result implements fmt.Formatter, renders it via "%+v". Go
+// requires exact return-type identity for interface satisfaction, so a
+// fixed interface like `StackTrace() interface{ Format(...) }` can never
+// be satisfied by github.com/pkg/errors (whose StackTrace() returns its
+// own named errors.StackTrace type) or by any similarly-shaped library;
+// reflection lets us accept any such method regardless of its declared
+// return type.
+func errorStackFrames(e error) (string, bool) {
+	m := reflect.ValueOf(e).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return "", false
+	}
+
+	out := m.Call(nil)[0]
+	formatter, ok := out.Interface().(fmt.Formatter)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%+v", formatter), true
+}
+
+// captureStack returns a (possibly multi-line) stack trace for err,
+// walking its unwrap chain. Native frames (via a StackTrace() method, see
+// errorStackFrames, or a fmt.Formatter whose "%+v" differs from Error())
+// are always included; otherwise a best-effort runtime.Callers capture is
+// used at Error level and above, or whenever forceStackTrace is set.
+func (h *ECSHandler) captureStack(err error, level slog.Level) string {
+	var frames []string
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if s, ok := errorStackFrames(e); ok {
+			frames = append(frames, s)
+			continue
+		}
+		if t, ok := e.(fmt.Formatter); ok {
+			if s := fmt.Sprintf("%+v", t); s != e.Error() {
+				frames = append(frames, s)
+			}
+		}
+	}
+
+	if len(frames) > 0 {
+		return strings.Join(frames, "\n")
+	}
+
+	if level >= slog.LevelError || h.forceStackTrace {
+		return captureRuntimeStack()
+	}
+
+	return ""
+}
+
+// captureRuntimeStack renders the current goroutine's stack via
+// runtime.Callers, for errors that don't embed their own frames.
+func captureRuntimeStack() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(4, pcs)
+
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			break
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
 }
@@ -4,13 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"runtime"
 	"strings"
 	"testing"
 	"time"
 
-	"github.com/simodima/serene/log"
+	"github.com/simodima/serene/log/ecsattrs"
 )
 
 func TestECSHandler(t *testing.T) {
@@ -24,7 +26,7 @@ func TestECSHandler(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	ctx = log.AddLabelAttrs(ctx, slog.String("custom_key", "custom_value"))
+	ctx = ecsattrs.AddLabels(ctx, slog.String("custom_key", "custom_value"))
 
 	// Prepare: Create a sample slog.Record
 	record := slog.Record{
@@ -90,6 +92,207 @@ func TestECSHandler(t *testing.T) {
 	}
 }
 
+func TestECSHandler_ErrorAttr(t *testing.T) {
+	var buf bytes.Buffer
+	handler := &ECSHandler{
+		JSONHandler:  slog.NewJSONHandler(&buf, &slog.HandlerOptions{}),
+		levelRenamer: func(level slog.Level) string { return level.String() },
+	}
+
+	record := slog.Record{
+		Time:    time.Now(),
+		Level:   slog.LevelError,
+		Message: "boom",
+		PC:      getCurrentPC(),
+	}
+	record.AddAttrs(slog.Any("error", errors.New("something failed")))
+
+	if err := handler.Handle(context.Background(), record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var logOutput map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &logOutput); err != nil {
+		t.Fatalf("failed to parse log output: %v", err)
+	}
+
+	errGroup, ok := logOutput[errorKey].(map[string]interface{})
+	if !ok {
+		t.Fatalf("missing %q key or invalid value in log output", errorKey)
+	}
+
+	if errGroup[errorMessageKey] != "something failed" {
+		t.Errorf("unexpected %q value: got %v, want %q", errorMessageKey, errGroup[errorMessageKey], "something failed")
+	}
+	if errGroup[errorTypeKey] != "*errors.errorString" {
+		t.Errorf("unexpected %q value: got %v, want %q", errorTypeKey, errGroup[errorTypeKey], "*errors.errorString")
+	}
+	if _, ok := errGroup[errorStackTraceKey]; !ok {
+		t.Errorf("missing %q key in error group for an Error-level record", errorStackTraceKey)
+	}
+}
+
+// fakeStack mimics the type github.com/pkg/errors.StackTrace returns from
+// StackTrace(): a named type with its own Format method, not fmt.Formatter
+// on the error itself.
+type fakeStack struct{ frames []string }
+
+func (s fakeStack) Format(f fmt.State, _ rune) {
+	for _, fr := range s.frames {
+		_, _ = fmt.Fprintln(f, fr)
+	}
+}
+
+// stackError carries frames only via StackTrace(); unlike fmt.Formatter
+// errors, it exercises the errorStackFrames reflection path exclusively.
+type stackError struct {
+	msg   string
+	stack fakeStack
+}
+
+func (e *stackError) Error() string         { return e.msg }
+func (e *stackError) StackTrace() fakeStack { return e.stack }
+
+func TestECSHandler_ErrorAttr_StackTraceMethod(t *testing.T) {
+	var buf bytes.Buffer
+	handler := &ECSHandler{
+		JSONHandler:  slog.NewJSONHandler(&buf, &slog.HandlerOptions{}),
+		levelRenamer: func(level slog.Level) string { return level.String() },
+	}
+
+	err := &stackError{msg: "boom", stack: fakeStack{frames: []string{"main.fn /tmp/main.go:42"}}}
+	record := slog.Record{Time: time.Now(), Level: slog.LevelWarn, Message: "boom", PC: getCurrentPC()}
+	record.AddAttrs(slog.Any("error", err))
+
+	if err := handler.Handle(context.Background(), record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var logOutput map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &logOutput); err != nil {
+		t.Fatalf("failed to parse log output: %v", err)
+	}
+
+	errGroup, ok := logOutput[errorKey].(map[string]interface{})
+	if !ok {
+		t.Fatalf("missing %q key or invalid value in log output", errorKey)
+	}
+
+	stack, _ := errGroup[errorStackTraceKey].(string)
+	if !strings.Contains(stack, "main.fn /tmp/main.go:42") {
+		t.Errorf("expected %q to contain StackTrace()'s rendered frames, got %q", errorStackTraceKey, stack)
+	}
+}
+
+func TestECSHandler_Namespace(t *testing.T) {
+	var buf bytes.Buffer
+	prefix := "http"
+	handler := &ECSHandler{
+		JSONHandler:  slog.NewJSONHandler(&buf, &slog.HandlerOptions{}),
+		levelRenamer: func(level slog.Level) string { return level.String() },
+		namespace:    &prefix,
+	}
+
+	record := slog.Record{Time: time.Now(), Level: slog.LevelInfo, Message: "served", PC: getCurrentPC()}
+	record.AddAttrs(slog.Int("http.response.status_code", 200), slog.String("other", "untouched"))
+
+	if err := handler.Handle(context.Background(), record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var logOutput map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &logOutput); err != nil {
+		t.Fatalf("failed to parse log output: %v", err)
+	}
+
+	http, ok := logOutput["http"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("missing %q key or invalid value in log output", "http")
+	}
+	response, ok := http["response"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("missing %q key or invalid value in http group", "response")
+	}
+	if response["status_code"] != float64(200) {
+		t.Errorf("unexpected %q value: got %v, want 200", "status_code", response["status_code"])
+	}
+	if logOutput["other"] != "untouched" {
+		t.Errorf("unexpected %q value: got %v, want %q", "other", logOutput["other"], "untouched")
+	}
+}
+
+func TestECSHandler_WithGroup_KeepsMetadataTopLevel(t *testing.T) {
+	var buf bytes.Buffer
+	handler := NewECSHandler(WithWriter(&buf), WithLevelRenamer(func(level slog.Level) string { return level.String() }))
+
+	grouped := handler.WithGroup("req").(*ECSHandler)
+
+	ctx := ecsattrs.AddLabels(context.Background(), slog.String("custom_key", "custom_value"))
+	record := slog.Record{Time: time.Now(), Level: slog.LevelInfo, Message: "served", PC: getCurrentPC()}
+	record.AddAttrs(slog.Int("status", 200))
+
+	if err := grouped.Handle(ctx, record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var logOutput map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &logOutput); err != nil {
+		t.Fatalf("failed to parse log output: %v\n%s", err, buf.String())
+	}
+
+	for _, key := range []string{messageKey, ecsVersionKey, logLevelKey, logLoggerKey, labelsKey, logOriginKey} {
+		if _, ok := logOutput[key]; !ok {
+			t.Errorf("expected %q to stay top-level after WithGroup, but it's missing from %v", key, logOutput)
+		}
+	}
+
+	req, ok := logOutput["req"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected record's own attrs to nest under %q, got %v", "req", logOutput)
+	}
+	if req["status"] != float64(200) {
+		t.Errorf("unexpected %q value: got %v, want 200", "status", req["status"])
+	}
+	if _, ok := req[messageKey]; ok {
+		t.Errorf("did not expect ECS metadata nested under %q", "req")
+	}
+}
+
+func TestECSHandler_MultipleErrorAttrs_KeepsAllOfThem(t *testing.T) {
+	var buf bytes.Buffer
+	handler := &ECSHandler{
+		JSONHandler:  slog.NewJSONHandler(&buf, &slog.HandlerOptions{}),
+		levelRenamer: func(level slog.Level) string { return level.String() },
+	}
+
+	record := slog.Record{Time: time.Now(), Level: slog.LevelError, Message: "boom", PC: getCurrentPC()}
+	record.AddAttrs(
+		slog.Any("primary_error", errors.New("primary failed")),
+		slog.Any("cause", errors.New("root cause")),
+	)
+
+	if err := handler.Handle(context.Background(), record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var logOutput map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &logOutput); err != nil {
+		t.Fatalf("failed to parse log output: %v\n%s", err, buf.String())
+	}
+
+	errGroup, ok := logOutput[errorKey].(map[string]interface{})
+	if !ok {
+		t.Fatalf("missing %q key or invalid value in log output", errorKey)
+	}
+	if errGroup[errorMessageKey] != "primary failed" {
+		t.Errorf("expected the first error attr to be promoted to the %q group, got %v", errorKey, errGroup[errorMessageKey])
+	}
+
+	if logOutput["cause"] != "root cause" {
+		t.Errorf("expected the second error attr to survive as %q, got %v", "cause", logOutput["cause"])
+	}
+}
+
 // Helper function to mock retrieving a function's PC (Program Counter) for testing
 func getCurrentPC() uintptr {
 	pc, _, _, _ := runtime.Caller(1)
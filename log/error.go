@@ -0,0 +1,16 @@
+package log
+
+import "log/slog"
+
+// errAttrKey is the conventional key used by Err. ECSHandler.Handle lifts
+// the first error-valued attribute of a record into the ECS error group
+// regardless of its key (later ones are kept as plain strings), but this is
+// the name you'll see if you build one by hand.
+const errAttrKey = "error"
+
+// Err returns a slog.Attr wrapping err, the canonical way to attach an
+// error to a log call so ECSHandler.Handle lifts it into the ECS error
+// group (error.message, error.type, and, when available, error.stack_trace).
+func Err(err error) slog.Attr {
+	return slog.Any(errAttrKey, err)
+}
@@ -2,113 +2,114 @@ package log
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"os"
-	"runtime"
-)
 
-const (
-	ecsVersion = "8.11.0"
-	logger     = "log/slog"
+	"golang.org/x/term"
+
+	"github.com/simodima/serene/log/ecshandler"
 )
 
+// Format selects the on-the-wire representation produced by NewHandler.
+type Format int
+
 const (
-	ecsVersionKey = "ecs.version"
-
-	timestampKey = "@timestamp"
-	messageKey   = "message"
-	logLevelKey  = "log.level"
-	logLoggerKey = "log.logger"
-	fileNameKey  = "file.name"
-	fileLineKey  = "file.line"
-	logOriginKey = "log.origin"
-	functionKey  = "function"
-	labelsKey    = "labels"
-
-	// errorKey           = "error"
-	// errorMessageKey    = "message"
-	// errorStackTraceKey = "stack_trace"
+	// FormatAuto picks FormatTerminal when the destination writer is a TTY
+	// and FormatJSON otherwise. It is the zero value, so it's the default
+	// when WithFormat isn't given.
+	FormatAuto Format = iota
+	FormatJSON
+	FormatLogfmt
+	FormatTerminal
 )
 
-type Option func(*opts)
-
-type opts struct {
-	level        slog.Level
-	levelRenamer func(slog.Level) string
-	replaceAttr  func(groups []string, a slog.Attr) slog.Attr
+// handlerOptions holds NewHandler/NewTerminalHandler configuration.
+type handlerOptions struct {
+	format     Format
+	ecsOptions []ecshandler.Option
+	noColor    bool
 }
 
-var defaultOptions = opts{
-	level:        slog.LevelDebug,
-	levelRenamer: func(level slog.Level) string { return level.String() },
-	replaceAttr: func(groups []string, a slog.Attr) slog.Attr {
-		switch a.Key {
-		case "time", "msg", "source", "level":
-			return slog.Attr{}
-		default:
-			return a
-		}
-	},
+// Option customizes NewHandler and NewTerminalHandler.
+type Option func(*handlerOptions)
+
+// WithFormat selects the handler implementation NewHandler builds.
+func WithFormat(f Format) Option {
+	return func(o *handlerOptions) {
+		o.format = f
+	}
 }
 
-func WithLevel(l slog.Level) Option {
-	return func(o *opts) {
-		o.level = l
+// WithECSOptions forwards options to the underlying ecshandler.ECSHandler
+// when NewHandler builds a FormatJSON handler.
+func WithECSOptions(opts ...ecshandler.Option) Option {
+	return func(o *handlerOptions) {
+		o.ecsOptions = append(o.ecsOptions, opts...)
 	}
 }
 
-func WithLevelRenamer(fn func(slog.Level) string) Option {
-	return func(o *opts) {
-		o.levelRenamer = fn
+// WithNoColor disables ANSI colors on the terminal handler, regardless of
+// TTY detection.
+func WithNoColor(noColor bool) Option {
+	return func(o *handlerOptions) {
+		o.noColor = noColor
 	}
 }
 
-func WithReplaceAttr(fn func(groups []string, a slog.Attr) slog.Attr) Option {
-	return func(o *opts) {
-		o.replaceAttr = fn
+// NewHandler builds an slog.Handler writing to w, choosing between the ECS
+// JSON handler, a logfmt handler, and the colorized terminal handler. With
+// FormatAuto (the default), it picks FormatTerminal when w is a TTY and
+// FormatJSON otherwise.
+func NewHandler(w io.Writer, opts ...Option) slog.Handler {
+	o := handlerOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	format := o.format
+	if format == FormatAuto {
+		format = FormatJSON
+		if isTerminal(w) {
+			format = FormatTerminal
+		}
+	}
+
+	switch format {
+	case FormatTerminal:
+		return NewTerminalHandler(w, opts...)
+	case FormatLogfmt:
+		return &contextHandler{Handler: slog.NewTextHandler(w, nil)}
+	default:
+		return ecshandler.NewECSHandler(append([]ecshandler.Option{ecshandler.WithWriter(w)}, o.ecsOptions...)...)
 	}
 }
 
-type ECSHandler struct {
-	*slog.JSONHandler
-	levelRenamer func(slog.Level) string
+// isTerminal reports whether w is a TTY, so NewHandler/NewTerminalHandler
+// skip color and FormatAuto's terminal detection for non-file writers
+// (e.g. a bytes.Buffer in tests).
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
 }
 
-func NewECSHandler(options ...Option) *ECSHandler {
-	o := defaultOptions
-	for _, op := range options {
-		op(&o)
-	}
+// contextHandler enriches a plain slog.Handler (e.g. logfmt) with the
+// request-scoped label and ECS attributes stashed in ctx by
+// HTTPAttributesMiddleware, mirroring what ECSHandler.Handle does natively.
+type contextHandler struct {
+	slog.Handler
+}
 
-	h := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level:       o.level,
-		ReplaceAttr: o.replaceAttr,
-	})
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(GetLabelAttrs(ctx)...)
+	r.AddAttrs(GetECSAttrs(ctx)...)
+	return h.Handler.Handle(ctx, r)
+}
 
-	return &ECSHandler{
-		JSONHandler:  h,
-		levelRenamer: o.levelRenamer,
-	}
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
 }
 
-func (h *ECSHandler) Handle(ctx context.Context, r slog.Record) error {
-	attrs := GetAttrsCtx(ctx)
-	fs := runtime.CallersFrames([]uintptr{r.PC})
-	f, _ := fs.Next()
-
-	r.AddAttrs(
-		slog.Time(timestampKey, r.Time),
-		slog.String(messageKey, r.Message),
-		slog.String(logLevelKey, h.levelRenamer(r.Level)),
-		slog.String(ecsVersionKey, ecsVersion),
-		slog.String(logLoggerKey, logger),
-		slog.Attr{Key: labelsKey, Value: slog.GroupValue(attrs...)},
-		slog.Group(logOriginKey,
-			slog.String(fileNameKey, f.File),
-			slog.Int(fileLineKey, f.Line),
-			slog.String(functionKey, f.Function),
-		),
-	)
-
-	return h.JSONHandler.Handle(ctx, r)
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
 }
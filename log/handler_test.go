@@ -0,0 +1,67 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/simodima/serene/log/ecshandler"
+)
+
+func TestNewHandler_FormatAutoPicksJSONForNonTTY(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf)
+	if _, ok := h.(*ecshandler.ECSHandler); !ok {
+		t.Fatalf("expected FormatAuto on a non-TTY writer to select the ECS JSON handler, got %T", h)
+	}
+}
+
+func TestNewHandler_FormatJSON(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, WithFormat(FormatJSON))
+
+	logger := slog.New(h)
+	logger.Info("hello")
+
+	if !strings.HasPrefix(strings.TrimSpace(buf.String()), "{") {
+		t.Errorf("expected JSON output, got %q", buf.String())
+	}
+}
+
+func TestNewHandler_FormatLogfmt(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, WithFormat(FormatLogfmt))
+
+	logger := slog.New(h)
+	logger.Info("hello")
+
+	out := buf.String()
+	if strings.HasPrefix(strings.TrimSpace(out), "{") {
+		t.Errorf("expected logfmt output, got JSON: %q", out)
+	}
+	if !strings.Contains(out, "msg=hello") {
+		t.Errorf("expected logfmt msg=hello, got %q", out)
+	}
+}
+
+func TestNewHandler_FormatTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, WithFormat(FormatTerminal))
+	if _, ok := h.(*TerminalHandler); !ok {
+		t.Fatalf("expected FormatTerminal to select *TerminalHandler, got %T", h)
+	}
+}
+
+func TestContextHandler_InjectsContextAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, WithFormat(FormatLogfmt))
+
+	ctx := AddLabelAttrs(context.Background(), slog.String("request_id", "abc"))
+	slog.New(h).InfoContext(ctx, "hello")
+
+	if out := buf.String(); !strings.Contains(out, "request_id=abc") {
+		t.Errorf("expected context label attrs in logfmt output, got %q", out)
+	}
+}
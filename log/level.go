@@ -0,0 +1,45 @@
+package log
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// levelPayload is the JSON body accepted/returned by LevelHandler.
+type levelPayload struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler returns an http.Handler, mountable next to
+// healthz.HealthzHandler, for inspecting and atomically changing the
+// minimum logging level of lv at runtime. GET reports the current level;
+// PUT or POST with a body like {"level":"debug"} sets it.
+func LevelHandler(lv *slog.LevelVar) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(levelPayload{Level: lv.Level().String()})
+
+		case http.MethodPut, http.MethodPost:
+			var payload levelPayload
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(payload.Level)); err != nil {
+				http.Error(w, "invalid level", http.StatusBadRequest)
+				return
+			}
+
+			lv.Set(level)
+			json.NewEncoder(w).Encode(levelPayload{Level: lv.Level().String()})
+
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package log
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevelHandler_Get(t *testing.T) {
+	lv := new(slog.LevelVar)
+	lv.Set(slog.LevelWarn)
+
+	rr := httptest.NewRecorder()
+	LevelHandler(lv).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"WARN"`) {
+		t.Errorf("expected body to report level WARN, got %q", rr.Body.String())
+	}
+}
+
+func TestLevelHandler_Put(t *testing.T) {
+	lv := new(slog.LevelVar)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"debug"}`))
+	LevelHandler(lv).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if lv.Level() != slog.LevelDebug {
+		t.Errorf("expected level to be set to Debug, got %v", lv.Level())
+	}
+}
+
+func TestLevelHandler_PutInvalidBody(t *testing.T) {
+	lv := new(slog.LevelVar)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`not json`))
+	LevelHandler(lv).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestLevelHandler_PutInvalidLevel(t *testing.T) {
+	lv := new(slog.LevelVar)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"not-a-level"}`))
+	LevelHandler(lv).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestLevelHandler_MethodNotAllowed(t *testing.T) {
+	lv := new(slog.LevelVar)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	LevelHandler(lv).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+}
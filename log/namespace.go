@@ -0,0 +1,17 @@
+package log
+
+import (
+	"log/slog"
+
+	"github.com/simodima/serene/log/ecsattrs"
+)
+
+// NamespaceAttrs rewrites attrs whose keys use ECS dotted notation (e.g.
+// "http.response.status_code") into nested slog groups, merging attrs that
+// share a common prefix under the same group. The returned Attr has an
+// empty key, so slog inlines its groups wherever it's added (e.g. via
+// r.AddAttrs or as a logging call argument) instead of nesting them under
+// an extra synthetic key.
+func NamespaceAttrs(attrs ...slog.Attr) slog.Attr {
+	return slog.Attr{Value: slog.GroupValue(ecsattrs.Namespace(attrs)...)}
+}
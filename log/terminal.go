@@ -0,0 +1,119 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// ANSI color codes used by TerminalHandler.
+const (
+	colorReset  = "\033[0m"
+	colorGray   = "\033[90m"
+	colorCyan   = "\033[36m"
+	colorYellow = "\033[33m"
+	colorRed    = "\033[31m"
+)
+
+var levelColors = map[slog.Level]string{
+	slog.LevelDebug: colorGray,
+	slog.LevelInfo:  colorCyan,
+	slog.LevelWarn:  colorYellow,
+	slog.LevelError: colorRed,
+}
+
+// TerminalHandler renders records in a developer-friendly format
+// (timestamp, colored level, message, then key=value pairs) while still
+// honoring GetLabelAttrs/GetECSAttrs context propagation.
+type TerminalHandler struct {
+	w      io.Writer
+	color  bool
+	attrs  []slog.Attr
+	groups []string
+	mu     *sync.Mutex
+}
+
+// NewTerminalHandler returns an slog.Handler that writes human-readable,
+// colorized records to w. Color is suppressed when w isn't a TTY, when
+// NO_COLOR is set, or when WithNoColor(true) is given.
+func NewTerminalHandler(w io.Writer, opts ...Option) *TerminalHandler {
+	o := handlerOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	color := !o.noColor && os.Getenv("NO_COLOR") == "" && isTerminal(w)
+
+	return &TerminalHandler{w: w, color: color, mu: &sync.Mutex{}}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+// TerminalHandler has no level filtering of its own: it reports true for
+// every level, so every record is written.
+func (h *TerminalHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle writes r to the underlying writer.
+func (h *TerminalHandler) Handle(ctx context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+
+	level := r.Level.String()
+	if h.color {
+		level = levelColors[r.Level] + level + colorReset
+	}
+
+	fmt.Fprintf(&buf, "%s %s %s", r.Time.Format(time.RFC3339), level, r.Message)
+
+	writeAttr := func(a slog.Attr) bool {
+		fmt.Fprintf(&buf, " %s=%v", a.Key, a.Value.Any())
+		return true
+	}
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	for _, a := range GetLabelAttrs(ctx) {
+		writeAttr(a)
+	}
+	for _, a := range GetECSAttrs(ctx) {
+		writeAttr(a)
+	}
+	r.Attrs(writeAttr)
+
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+// WithAttrs returns a new TerminalHandler with attrs appended to every
+// future record.
+func (h *TerminalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &TerminalHandler{
+		w:      h.w,
+		color:  h.color,
+		attrs:  append(append([]slog.Attr{}, h.attrs...), attrs...),
+		groups: h.groups,
+		mu:     h.mu,
+	}
+}
+
+// WithGroup returns a new TerminalHandler tracking name; TerminalHandler's
+// flat key=value rendering doesn't nest groups, and the tracked names are
+// not applied to the rendered keys of this or any derived handler.
+func (h *TerminalHandler) WithGroup(name string) slog.Handler {
+	return &TerminalHandler{
+		w:      h.w,
+		color:  h.color,
+		attrs:  h.attrs,
+		groups: append(append([]string{}, h.groups...), name),
+		mu:     h.mu,
+	}
+}
@@ -0,0 +1,60 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTerminalHandler_RendersKeyValuePairs(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewTerminalHandler(&buf, WithNoColor(true))
+
+	record := slog.Record{Time: time.Now(), Level: slog.LevelInfo, Message: "served"}
+	record.AddAttrs(slog.String("method", "GET"))
+
+	if err := h.Handle(context.Background(), record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "served") || !strings.Contains(out, "method=GET") {
+		t.Errorf("expected rendered line to contain message and attrs, got %q", out)
+	}
+	if strings.Contains(out, "\033[") {
+		t.Errorf("expected no ANSI escapes with WithNoColor(true), got %q", out)
+	}
+}
+
+func TestTerminalHandler_WithAttrsAppendsToFutureRecords(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewTerminalHandler(&buf, WithNoColor(true)).WithAttrs([]slog.Attr{slog.String("service", "api")})
+
+	record := slog.Record{Time: time.Now(), Level: slog.LevelInfo, Message: "served"}
+	if err := h.Handle(context.Background(), record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if out := buf.String(); !strings.Contains(out, "service=api") {
+		t.Errorf("expected WithAttrs attrs in output, got %q", out)
+	}
+}
+
+func TestTerminalHandler_IncludesContextAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewTerminalHandler(&buf, WithNoColor(true))
+
+	ctx := AddLabelAttrs(context.Background(), slog.String("request_id", "abc"))
+	record := slog.Record{Time: time.Now(), Level: slog.LevelInfo, Message: "served"}
+
+	if err := h.Handle(ctx, record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if out := buf.String(); !strings.Contains(out, "request_id=abc") {
+		t.Errorf("expected context label attrs in output, got %q", out)
+	}
+}